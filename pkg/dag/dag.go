@@ -1,13 +1,13 @@
 package dag
 
 import (
-	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
-	"golang.org/x/sync/errgroup"
-	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
 	gpath "gonum.org/v1/gonum/graph/path"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
@@ -17,12 +17,19 @@ import (
 type labeledNode struct {
 	simple.Node
 	label string
+	attrs []encoding.Attribute
 }
 
 func (ln *labeledNode) DOTID() string {
 	return ln.label
 }
 
+// Attributes implements encoding.Attributer, so a node whose GraphObject
+// implements DotStyler is rendered with its extra DOT attributes.
+func (ln *labeledNode) Attributes() []encoding.Attribute {
+	return ln.attrs
+}
+
 // GraphObject describes an object that will become a node in the graph
 type GraphObject interface {
 	Name() string   // the unique name for the object
@@ -43,15 +50,52 @@ type ObjectGraph struct {
 	idmap   map[int64]string
 	namemap map[string]int64
 	levels  [][]GraphObject
+	// cycles holds the dependency cycles detected at Build time, in the same
+	// order topo.DirectedCyclesIn returned them. It is only populated when
+	// the graph was built with BuildOptions{AllowCycles: true} and cycles
+	// were actually present.
+	cycles [][]GraphObject
+	// cyclicEdges is the set of edges that participate in at least one of
+	// cycles, keyed by (from, to) node ID. setRoot and calcLevels ignore
+	// these edges so a cyclic graph still has a well-defined root and levels.
+	cyclicEdges map[edgeKey]bool
+
+	statusMu sync.Mutex
+	// status tracks each node's lifecycle state during the most recent Walk
+	// or WalkWith call. See WalkStatus.
+	status map[string]NodeState
+}
+
+// edgeKey identifies a directed edge by its endpoint node IDs.
+type edgeKey struct {
+	from, to int64
 }
 
 func (og *ObjectGraph) init() {
 	og.idmap = make(map[int64]string)
 	og.namemap = make(map[string]int64)
 	og.levels = [][]GraphObject{}
+	og.cycles = nil
+	og.cyclicEdges = nil
 }
 
-func (og *ObjectGraph) populate(objs []GraphObject) error {
+// cyclicEdgeSet returns the set of edges that participate in at least one of
+// the supplied cycles.
+func cyclicEdgeSet(cycles [][]graph.Node) map[edgeKey]bool {
+	edges := make(map[edgeKey]bool)
+	for _, c := range cycles {
+		for i := range c {
+			from, to := c[i].ID(), c[(i+1)%len(c)].ID()
+			if from == to {
+				continue
+			}
+			edges[edgeKey{from: from, to: to}] = true
+		}
+	}
+	return edges
+}
+
+func (og *ObjectGraph) populate(objs []GraphObject, allowCycles bool) error {
 	dg := simple.NewDirectedGraph()
 	// add all nodes
 	for i, o := range objs {
@@ -64,7 +108,11 @@ func (og *ObjectGraph) populate(objs []GraphObject) error {
 		}
 		og.idmap[offset] = o.Name()
 		og.namemap[o.Name()] = offset
-		dg.AddNode(&labeledNode{Node: simple.Node(offset), label: o.String()})
+		ln := &labeledNode{Node: simple.Node(offset), label: o.String()}
+		if ds, ok := o.(DotStyler); ok {
+			ln.attrs = attrMapToAttributes(ds.DotAttributes())
+		}
+		dg.AddNode(ln)
 	}
 	// add all edges
 	for i, o := range objs {
@@ -79,7 +127,7 @@ func (og *ObjectGraph) populate(objs []GraphObject) error {
 			dg.SetEdge(dg.NewEdge(dg.Node(offset), dg.Node(og.namemap[d])))
 		}
 	}
-	if cycles := topo.DirectedCyclesIn(dg); len(cycles) > 0 {
+	if cycles := topo.DirectedCyclesIn(dg); len(cycles) > 0 && !allowCycles {
 		var cstrs []string
 		for _, c := range cycles {
 			var cstr []string
@@ -91,9 +139,33 @@ func (og *ObjectGraph) populate(objs []GraphObject) error {
 		return fmt.Errorf("dependency cycles found (%v): %v", len(cycles), strings.Join(cstrs, "; "))
 	}
 	og.g = dg
+	og.recomputeCycles()
 	return nil
 }
 
+// recomputeCycles refreshes og.cycles/og.cyclicEdges from the graph's
+// current topology. It must be called any time og.g's edges change (e.g.
+// after Build or an incremental edit in mutate.go), since a stale
+// cyclicEdges map can misattribute an edge as cyclic (or vice versa) once
+// node IDs are reused.
+func (og *ObjectGraph) recomputeCycles() {
+	cycles := topo.DirectedCyclesIn(og.g)
+	if len(cycles) == 0 {
+		og.cycles = nil
+		og.cyclicEdges = nil
+		return
+	}
+	og.cyclicEdges = cyclicEdgeSet(cycles)
+	og.cycles = make([][]GraphObject, len(cycles))
+	for i, c := range cycles {
+		cobjs := make([]GraphObject, len(c))
+		for j, n := range c {
+			cobjs[j] = og.objs[n.ID()]
+		}
+		og.cycles[i] = cobjs
+	}
+}
+
 const rootName = "__ROOT__"
 
 type synthRoot struct {
@@ -110,11 +182,23 @@ func (sr *synthRoot) Dependencies() []string {
 	return sr.deps
 }
 
+// nonCyclicIndegree returns the number of incoming edges to node id, ignoring
+// any edge that participates in a detected dependency cycle.
+func (og *ObjectGraph) nonCyclicIndegree(id int64) int {
+	n := 0
+	for _, p := range og.g.To(id) {
+		if !og.cyclicEdges[edgeKey{from: p.ID(), to: id}] {
+			n++
+		}
+	}
+	return n
+}
+
 // setRoot finds the root of the graph or synthetically creates one if there are multiple
 func (og *ObjectGraph) setRoot() error {
 	roots := []int64{}
 	for k := range og.idmap {
-		if len(og.g.To(k)) == 0 {
+		if og.nonCyclicIndegree(k) == 0 {
 			roots = append(roots, k)
 		}
 	}
@@ -146,6 +230,9 @@ func (og *ObjectGraph) calcLevels() {
 		wdg.AddNode(n)
 	}
 	for _, e := range og.g.Edges() {
+		if og.cyclicEdges[edgeKey{from: e.From().ID(), to: e.To().ID()}] {
+			continue
+		}
 		wdg.SetWeightedEdge(wdg.NewWeightedEdge(e.From(), e.To(), -1))
 	}
 	pt, _ := gpath.BellmanFordFrom(wdg.Node(og.root), wdg) // negative cycles are impossible because this is a DAG
@@ -160,11 +247,29 @@ func (og *ObjectGraph) calcLevels() {
 	}
 }
 
-// Build populates the graph with the supplied objects
+// BuildOptions controls how Build constructs the graph.
+type BuildOptions struct {
+	// AllowCycles permits the graph to be built even when the supplied objects
+	// contain dependency cycles. The cyclic edges are kept in the graph (so
+	// they can be inspected via Cycles or drawn via DotWithOptions) but are
+	// ignored when computing the graph root and levels. Info does not report
+	// the cycles.
+	AllowCycles bool
+}
+
+// Build populates the graph with the supplied objects. It fails if the
+// objects contain a dependency cycle; use BuildWithOptions to tolerate one.
 func (og *ObjectGraph) Build(objs []GraphObject) error {
+	return og.BuildWithOptions(objs, BuildOptions{})
+}
+
+// BuildWithOptions populates the graph with the supplied objects, as Build
+// does, but allows dependency cycles to be tolerated via opts.AllowCycles
+// rather than treated as a fatal error.
+func (og *ObjectGraph) BuildWithOptions(objs []GraphObject, opts BuildOptions) error {
 	og.init()
 	og.objs = objs
-	if err := og.populate(objs); err != nil {
+	if err := og.populate(objs, opts.AllowCycles); err != nil {
 		return errors.Wrap(err, "error populating graph")
 	}
 	if err := og.setRoot(); err != nil {
@@ -182,37 +287,9 @@ func (og *ObjectGraph) Info() (root GraphObject, levels [][]GraphObject, err err
 	return og.objs[og.root], og.levels, nil
 }
 
-// Dot returns the GraphWiz DOT output for the graph
-func (og *ObjectGraph) Dot(name string) ([]byte, error) {
-	b, err := dot.Marshal(og.g, name, "", "    ", true)
-	if err != nil {
-		return nil, errors.Wrap(err, "error marshaling graph to dot")
-	}
-	return b, nil
-}
-
-// ActionFunc is a function that is executed for each node in the graph. Returning an error will cause the graph walk to abort.
-type ActionFunc func(GraphObject) error
-
-// Walk traverses the graph levels in decending order, executing af for every node in a given level concurrently
-func (og *ObjectGraph) Walk(ctx context.Context, af ActionFunc) error {
-	var g errgroup.Group
-	for i := len(og.levels) - 1; i >= 0; i-- {
-		for j := range og.levels[i] {
-			select {
-			case <-ctx.Done():
-				return errors.New("context was cancelled")
-			default:
-			}
-			obj := og.levels[i][j]
-			if obj.Name() == rootName {
-				continue
-			}
-			g.Go(func() error { return af(obj) })
-		}
-		if err := g.Wait(); err != nil {
-			return errors.Wrapf(err, "error executing level %v", i)
-		}
-	}
-	return nil
+// Cycles returns the dependency cycles detected when the graph was built. It
+// is empty unless the graph was built with BuildOptions{AllowCycles: true}
+// and the supplied objects actually contained cycles.
+func (og *ObjectGraph) Cycles() [][]GraphObject {
+	return og.cycles
 }
@@ -0,0 +1,52 @@
+package dag
+
+import "testing"
+
+// testObj is a minimal GraphObject used throughout this package's tests.
+type testObj struct {
+	name string
+	deps []string
+}
+
+func (o *testObj) Name() string           { return o.name }
+func (o *testObj) String() string         { return o.name }
+func (o *testObj) Dependencies() []string { return o.deps }
+
+func obj(name string, deps ...string) *testObj {
+	return &testObj{name: name, deps: deps}
+}
+
+func TestBuildRejectsCycle(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b", "a")}); err == nil {
+		t.Fatal("expected an error for a cyclic graph")
+	}
+}
+
+func TestBuildRejectsUnknownDependency(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "missing")}); err == nil {
+		t.Fatal("expected an error for an unknown dependency")
+	}
+}
+
+func TestBuildWithOptionsAllowsCycle(t *testing.T) {
+	og := &ObjectGraph{}
+	err := og.BuildWithOptions([]GraphObject{obj("a", "b"), obj("b", "a"), obj("c")}, BuildOptions{AllowCycles: true})
+	if err != nil {
+		t.Fatalf("expected a cyclic graph to build with AllowCycles: %v", err)
+	}
+	if len(og.Cycles()) == 0 {
+		t.Fatal("expected Cycles() to report the detected cycle")
+	}
+	root, levels, err := og.Info()
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if root == nil {
+		t.Fatal("expected a non-nil root")
+	}
+	if len(levels) == 0 {
+		t.Fatal("expected at least one level")
+	}
+}
@@ -0,0 +1,122 @@
+package dag
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// DotStyler is an optional extension to GraphObject. Implementations can
+// inject extra DOT attributes (e.g. shape, color, fillcolor, tooltip, URL)
+// into the node emitted for them by Dot/DotWithOptions.
+type DotStyler interface {
+	DotAttributes() map[string]string
+}
+
+// EdgeStyler is an optional extension to GraphObject. Implementations can
+// annotate the DOT edges drawn for their own dependencies (e.g. to draw a
+// "weak" dependency dashed), keyed by the dependency's name.
+type EdgeStyler interface {
+	EdgeAttributes(dependency string) map[string]string
+}
+
+// attrMapToAttributes converts a DotStyler/EdgeStyler attribute map into
+// encoding.Attributes in a deterministic (sorted by key) order.
+func attrMapToAttributes(m map[string]string) []encoding.Attribute {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]encoding.Attribute, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, encoding.Attribute{Key: k, Value: m[k]})
+	}
+	return attrs
+}
+
+// DotOptions controls the GraphViz DOT output produced by DotWithOptions.
+type DotOptions struct {
+	// DrawCycles renders edges that participate in a detected dependency
+	// cycle (see BuildOptions.AllowCycles) in a distinct style (red, dashed,
+	// constraint=false) so they stand out from the rest of the graph.
+	DrawCycles bool
+	// MaxDepth limits the rendered graph to nodes at most MaxDepth levels
+	// from the root, inclusive. A MaxDepth of 0 means no limit.
+	MaxDepth int
+}
+
+// styledEdge wraps a graph.Edge with DOT attributes so it is rendered with a
+// distinct style by dot.Marshal.
+type styledEdge struct {
+	graph.Edge
+	attrs []encoding.Attribute
+}
+
+// Attributes implements encoding.Attributer.
+func (e styledEdge) Attributes() []encoding.Attribute {
+	return e.attrs
+}
+
+// Dot returns the GraphViz DOT output for the graph.
+func (og *ObjectGraph) Dot(name string) ([]byte, error) {
+	return og.DotWithOptions(name, DotOptions{})
+}
+
+// DotWithOptions returns the GraphViz DOT output for the graph, as Dot does,
+// but additionally allows cyclic edges to be highlighted and the rendered
+// depth to be bounded.
+func (og *ObjectGraph) DotWithOptions(name string, opts DotOptions) ([]byte, error) {
+	include := map[int64]bool{}
+	if opts.MaxDepth > 0 {
+		for lvl, objs := range og.levels {
+			if lvl > opts.MaxDepth {
+				continue
+			}
+			for _, o := range objs {
+				include[og.namemap[o.Name()]] = true
+			}
+		}
+	}
+	out := simple.NewDirectedGraph()
+	for _, n := range og.g.Nodes() {
+		if opts.MaxDepth > 0 && !include[n.ID()] {
+			continue
+		}
+		out.AddNode(n)
+	}
+	for _, e := range og.g.Edges() {
+		from, to := e.From().ID(), e.To().ID()
+		if opts.MaxDepth > 0 && (!include[from] || !include[to]) {
+			continue
+		}
+		var attrs []encoding.Attribute
+		if opts.DrawCycles && og.cyclicEdges[edgeKey{from: from, to: to}] {
+			attrs = append(attrs,
+				encoding.Attribute{Key: "color", Value: "red"},
+				encoding.Attribute{Key: "style", Value: "dashed"},
+				encoding.Attribute{Key: "constraint", Value: "false"},
+			)
+		}
+		if es, ok := og.objs[from].(EdgeStyler); ok {
+			attrs = append(attrs, attrMapToAttributes(es.EdgeAttributes(og.idmap[to]))...)
+		}
+		if len(attrs) > 0 {
+			out.SetEdge(styledEdge{Edge: e, attrs: attrs})
+			continue
+		}
+		out.SetEdge(e)
+	}
+	b, err := dot.Marshal(out, name, "", "    ", true)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling graph to dot")
+	}
+	return b, nil
+}
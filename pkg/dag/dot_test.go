@@ -0,0 +1,75 @@
+package dag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotWithOptionsDrawsCycles(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.BuildWithOptions([]GraphObject{obj("a", "b"), obj("b", "a")}, BuildOptions{AllowCycles: true}); err != nil {
+		t.Fatalf("BuildWithOptions: %v", err)
+	}
+	b, err := og.DotWithOptions("test", DotOptions{DrawCycles: true})
+	if err != nil {
+		t.Fatalf("DotWithOptions: %v", err)
+	}
+	if !strings.Contains(string(b), "red") {
+		t.Errorf("expected cyclic edges to be drawn in red, got:\n%s", b)
+	}
+}
+
+// styledObj adds DotStyler/EdgeStyler support on top of testObj.
+type styledObj struct {
+	*testObj
+	nodeAttrs map[string]string
+	edgeAttrs map[string]string
+}
+
+func (o *styledObj) DotAttributes() map[string]string { return o.nodeAttrs }
+
+func (o *styledObj) EdgeAttributes(dependency string) map[string]string { return o.edgeAttrs }
+
+func TestDotStylerAddsNodeAttributes(t *testing.T) {
+	og := &ObjectGraph{}
+	a := &styledObj{testObj: obj("a"), nodeAttrs: map[string]string{"shape": "box"}}
+	if err := og.Build([]GraphObject{a}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	b, err := og.Dot("test")
+	if err != nil {
+		t.Fatalf("Dot: %v", err)
+	}
+	if !strings.Contains(string(b), "shape") {
+		t.Errorf("expected DotStyler attributes in output, got:\n%s", b)
+	}
+}
+
+func TestEdgeStylerAddsEdgeAttributes(t *testing.T) {
+	og := &ObjectGraph{}
+	a := &styledObj{testObj: obj("a", "b"), edgeAttrs: map[string]string{"style": "dashed"}}
+	if err := og.Build([]GraphObject{a, obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	b, err := og.Dot("test")
+	if err != nil {
+		t.Fatalf("Dot: %v", err)
+	}
+	if !strings.Contains(string(b), "dashed") {
+		t.Errorf("expected EdgeStyler attributes in output, got:\n%s", b)
+	}
+}
+
+func TestDotWithOptionsMaxDepth(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b", "c"), obj("c")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	b, err := og.DotWithOptions("test", DotOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("DotWithOptions: %v", err)
+	}
+	if s := string(b); strings.Contains(s, "c;") || strings.Contains(s, `"c"`) {
+		t.Errorf("expected node c to be excluded beyond MaxDepth, got:\n%s", b)
+	}
+}
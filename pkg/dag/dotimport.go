@@ -0,0 +1,114 @@
+package dag
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// dotNode is the node type used while unmarshaling a DOT document. It records
+// the DOT ID and attributes assigned to it by the parser. Once the whole
+// document has been read, only the ID and the "label" attribute are handed
+// to the caller-supplied factory; other node attributes are not passed on.
+type dotNode struct {
+	graph.Node
+	id    string
+	attrs []encoding.Attribute
+}
+
+// SetDOTID implements dot.DOTIDSetter.
+func (n *dotNode) SetDOTID(id string) {
+	n.id = id
+}
+
+// SetAttribute implements encoding.AttributeSetter.
+func (n *dotNode) SetAttribute(attr encoding.Attribute) error {
+	n.attrs = append(n.attrs, attr)
+	return nil
+}
+
+// label returns the node's DOT "label" attribute, falling back to its DOT ID
+// when no label was set.
+func (n *dotNode) label() string {
+	for _, a := range n.attrs {
+		if a.Key == "label" {
+			return a.Value
+		}
+	}
+	return n.id
+}
+
+// dotBuilder is the destination passed to dot.Unmarshal. It implements
+// graph.Builder so the parser can populate it, and encoding.AttributeSetter
+// to record graph-level attributes, while delegating storage to a plain
+// simple.DirectedGraph. The recorded graph-level attributes are not carried
+// into the ObjectGraph that UnmarshalDot returns.
+type dotBuilder struct {
+	*simple.DirectedGraph
+	attrs []encoding.Attribute
+}
+
+func newDotBuilder() *dotBuilder {
+	return &dotBuilder{DirectedGraph: simple.NewDirectedGraph()}
+}
+
+// NewNode returns a dotNode so the parser has somewhere to record the DOT ID
+// and attributes it encounters.
+func (b *dotBuilder) NewNode() graph.Node {
+	return &dotNode{Node: b.DirectedGraph.NewNode()}
+}
+
+// SetAttribute implements encoding.AttributeSetter for graph-level attributes.
+func (b *dotBuilder) SetAttribute(attr encoding.Attribute) error {
+	b.attrs = append(b.attrs, attr)
+	return nil
+}
+
+// dotGraphObject wraps a GraphObject produced by an UnmarshalDot factory so
+// that its Dependencies() reflect the edges found in the DOT document, rather
+// than whatever the wrapped object itself would otherwise return.
+type dotGraphObject struct {
+	GraphObject
+	deps []string
+}
+
+func (o *dotGraphObject) Dependencies() []string {
+	return o.deps
+}
+
+// UnmarshalDot parses a GraphViz DOT document and reconstructs an ObjectGraph
+// from it. factory is called once per DOT node with its ID and label
+// (falling back to the ID when no label attribute was set) and must return a
+// GraphObject to represent it; edges in the DOT document become
+// Dependencies() of the GraphObject at the edge's "from" end. Once every
+// object has been recovered, UnmarshalDot runs the same cycle/root/levels
+// pipeline as Build, so the resulting graph is immediately walkable.
+func UnmarshalDot(data []byte, factory func(id, label string) GraphObject) (*ObjectGraph, error) {
+	b := newDotBuilder()
+	if err := dot.Unmarshal(data, b); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling dot document")
+	}
+	nodes := b.Nodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	byID := make(map[int64]*dotGraphObject, len(nodes))
+	objs := make([]GraphObject, 0, len(nodes))
+	for _, n := range nodes {
+		dn := n.(*dotNode)
+		wrapped := &dotGraphObject{GraphObject: factory(dn.id, dn.label())}
+		byID[dn.ID()] = wrapped
+		objs = append(objs, wrapped)
+	}
+	for _, e := range b.Edges() {
+		from, to := byID[e.From().ID()], byID[e.To().ID()]
+		from.deps = append(from.deps, to.Name())
+	}
+	og := &ObjectGraph{}
+	if err := og.Build(objs); err != nil {
+		return nil, errors.Wrap(err, "error building graph from dot document")
+	}
+	return og, nil
+}
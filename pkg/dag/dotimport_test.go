@@ -0,0 +1,33 @@
+package dag
+
+import "testing"
+
+func TestUnmarshalDotRoundTrips(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	b, err := og.Dot("test")
+	if err != nil {
+		t.Fatalf("Dot: %v", err)
+	}
+
+	got, err := UnmarshalDot(b, func(id, label string) GraphObject {
+		return obj(label)
+	})
+	if err != nil {
+		t.Fatalf("UnmarshalDot: %v", err)
+	}
+	root, levels, err := got.Info()
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if root == nil || len(levels) == 0 {
+		t.Fatal("expected a non-empty reconstructed graph")
+	}
+	a, aok := got.namemap["a"]
+	bID, bok := got.namemap["b"]
+	if !aok || !bok || !got.g.HasEdgeFromTo(a, bID) {
+		t.Error("expected dependency b to round-trip as an edge")
+	}
+}
@@ -0,0 +1,208 @@
+package dag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// AddObject adds a new object to an already-built graph, wiring up its
+// declared Dependencies(). The edit is rejected, and the graph left
+// unchanged, if it would introduce a dependency cycle, references an unknown
+// dependency, or reuses an existing name.
+func (og *ObjectGraph) AddObject(o GraphObject) error {
+	if og.g == nil {
+		return errors.New("graph has not been built")
+	}
+	if o.Name() == "" {
+		return errors.New("empty object name")
+	}
+	if o.Name() == rootName {
+		return fmt.Errorf("reserved name: %v", rootName)
+	}
+	if _, ok := og.namemap[o.Name()]; ok {
+		return fmt.Errorf("object already exists: %v", o.Name())
+	}
+	for _, d := range o.Dependencies() {
+		if _, ok := og.namemap[d]; !ok {
+			return fmt.Errorf("unknown dependency (of %v): %v", o.Name(), d)
+		}
+	}
+
+	offset := int64(0)
+	for {
+		if _, ok := og.idmap[offset]; !ok {
+			break
+		}
+		offset++
+	}
+
+	ln := &labeledNode{Node: simple.Node(offset), label: o.String()}
+	if ds, ok := o.(DotStyler); ok {
+		ln.attrs = attrMapToAttributes(ds.DotAttributes())
+	}
+	og.g.AddNode(ln)
+	for _, d := range o.Dependencies() {
+		og.g.SetEdge(og.g.NewEdge(og.g.Node(offset), og.g.Node(og.namemap[d])))
+	}
+	if err := og.checkAcyclic(); err != nil {
+		og.g.RemoveNode(offset) // also removes the edges we just added
+		return err
+	}
+
+	og.idmap[offset] = o.Name()
+	og.namemap[o.Name()] = offset
+	og.setObjAt(offset, o)
+	if err := og.rebuild(); err != nil {
+		og.g.RemoveNode(offset)
+		delete(og.idmap, offset)
+		delete(og.namemap, o.Name())
+		og.objs[offset] = nil
+		return err
+	}
+	return nil
+}
+
+// RemoveObject removes the named object from the graph. It fails, leaving
+// the graph unchanged, if another object still declares it as a dependency.
+func (og *ObjectGraph) RemoveObject(name string) error {
+	if og.g == nil {
+		return errors.New("graph has not been built")
+	}
+	offset, ok := og.namemap[name]
+	if !ok {
+		return fmt.Errorf("object not found: %v", name)
+	}
+	for _, o := range og.objs {
+		if o == nil || o.Name() == name || o.Name() == rootName {
+			continue
+		}
+		for _, d := range o.Dependencies() {
+			if d == name {
+				return fmt.Errorf("cannot remove %v: %v depends on it", name, o.Name())
+			}
+		}
+	}
+	og.g.RemoveNode(offset)
+	delete(og.idmap, offset)
+	delete(og.namemap, name)
+	og.objs[offset] = nil
+	return og.rebuild()
+}
+
+// AppendDependencies adds additional dependencies to an existing object,
+// i.e. additional edges from it to the named objects. The edit is rejected,
+// and the graph left unchanged, if it would introduce a dependency cycle or
+// references an object that doesn't exist.
+func (og *ObjectGraph) AppendDependencies(name string, deps ...string) error {
+	if og.g == nil {
+		return errors.New("graph has not been built")
+	}
+	offset, ok := og.namemap[name]
+	if !ok {
+		return fmt.Errorf("object not found: %v", name)
+	}
+	added := make([]int64, 0, len(deps))
+	for _, d := range deps {
+		depOffset, ok := og.namemap[d]
+		if !ok {
+			og.removeEdges(offset, added)
+			return fmt.Errorf("unknown dependency (of %v): %v", name, d)
+		}
+		if depOffset == offset { // SetEdge panics on this
+			og.removeEdges(offset, added)
+			return fmt.Errorf("dependency references itself on %v", name)
+		}
+		if og.g.HasEdgeFromTo(offset, depOffset) { // already present; not ours to roll back
+			continue
+		}
+		og.g.SetEdge(og.g.NewEdge(og.g.Node(offset), og.g.Node(depOffset)))
+		added = append(added, depOffset)
+	}
+	if err := og.checkAcyclic(); err != nil {
+		og.removeEdges(offset, added)
+		return err
+	}
+	if err := og.rebuild(); err != nil {
+		og.removeEdges(offset, added)
+		return err
+	}
+	return nil
+}
+
+// removeEdges removes the edges from -> tos[i] for each tos[i], used to roll
+// back a partially-applied edit.
+func (og *ObjectGraph) removeEdges(from int64, tos []int64) {
+	for _, to := range tos {
+		og.g.RemoveEdge(from, to)
+	}
+}
+
+// setObjAt records o as the object for node ID offset, growing og.objs if
+// necessary. Offsets reused from a removed object leave no gap; offsets
+// beyond the current end do.
+func (og *ObjectGraph) setObjAt(offset int64, o GraphObject) {
+	if int64(len(og.objs)) <= offset {
+		grown := make([]GraphObject, offset+1)
+		copy(grown, og.objs)
+		og.objs = grown
+	}
+	og.objs[offset] = o
+}
+
+// checkAcyclic returns an error if the edit just applied to og.g introduced a
+// dependency cycle that wasn't already present beforehand. og.cyclicEdges
+// (as of the last successful Build/rebuild) is the pre-edit baseline, so a
+// graph built with BuildOptions{AllowCycles: true} can keep its existing
+// cycles without every later edit being rejected for them; only a genuinely
+// new cyclic edge causes this to fail.
+func (og *ObjectGraph) checkAcyclic() error {
+	cycles := topo.DirectedCyclesIn(og.g)
+	if len(cycles) == 0 {
+		return nil
+	}
+	introduced := false
+	for k := range cyclicEdgeSet(cycles) {
+		if !og.cyclicEdges[k] {
+			introduced = true
+			break
+		}
+	}
+	if !introduced {
+		return nil
+	}
+	var cstrs []string
+	for _, c := range cycles {
+		var cstr []string
+		for _, n := range c {
+			cstr = append(cstr, og.idmap[n.ID()])
+		}
+		cstrs = append(cstrs, strings.Join(cstr, " -> "))
+	}
+	return fmt.Errorf("dependency cycles found (%v): %v", len(cycles), strings.Join(cstrs, "; "))
+}
+
+// rebuild recomputes the graph root and levels after an incremental edit,
+// discarding any previously synthesized root since the set of roots may have
+// changed.
+func (og *ObjectGraph) rebuild() error {
+	if offset, ok := og.namemap[rootName]; ok {
+		og.g.RemoveNode(offset)
+		delete(og.idmap, offset)
+		delete(og.namemap, rootName)
+		og.objs[offset] = nil
+		if int64(len(og.objs)) == offset+1 {
+			og.objs = og.objs[:offset]
+		}
+	}
+	og.recomputeCycles()
+	og.levels = [][]GraphObject{}
+	if err := og.setRoot(); err != nil {
+		return errors.Wrap(err, "error getting graph root")
+	}
+	og.calcLevels()
+	return nil
+}
@@ -0,0 +1,112 @@
+package dag
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddObjectRejectsUnknownDependency(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := og.AddObject(obj("b", "missing")); err == nil {
+		t.Fatal("expected an error for an unknown dependency")
+	}
+	if _, ok := og.namemap["b"]; ok {
+		t.Error("expected the rejected object not to be added")
+	}
+}
+
+func TestAppendDependenciesRejectsCycleAndRollsBack(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := og.AddObject(obj("b", "a")); err != nil {
+		t.Fatalf("AddObject: %v", err)
+	}
+	if err := og.AppendDependencies("a", "b"); err == nil {
+		t.Fatal("expected an error introducing a cycle")
+	}
+	if og.g.HasEdgeFromTo(og.namemap["a"], og.namemap["b"]) {
+		t.Error("expected the rejected edge to have been rolled back")
+	}
+}
+
+func TestRemoveObjectThenWalkDoesNotPanic(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := og.RemoveObject("b"); err != nil {
+		t.Fatalf("RemoveObject: %v", err)
+	}
+	if err := og.Walk(context.Background(), func(o GraphObject) error { return nil }); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+}
+
+func TestRemoveObjectAllowsRemovingACurrentRoot(t *testing.T) {
+	og := &ObjectGraph{}
+	// a and b are independent roots, so Build synthesizes a __ROOT__ object
+	// that depends on both.
+	if err := og.Build([]GraphObject{obj("a"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := og.RemoveObject("a"); err != nil {
+		t.Fatalf("expected removing a root-level object to succeed, got: %v", err)
+	}
+}
+
+func TestRemoveObjectRejectsWhenDependedOn(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := og.RemoveObject("b"); err == nil {
+		t.Fatal("expected an error removing a dependency that's still in use")
+	}
+}
+
+func TestAddObjectOnAllowCyclesGraphDoesNotRejectUnrelatedEdit(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.BuildWithOptions([]GraphObject{obj("a", "b"), obj("b", "a")}, BuildOptions{AllowCycles: true}); err != nil {
+		t.Fatalf("BuildWithOptions: %v", err)
+	}
+	if err := og.AddObject(obj("c")); err != nil {
+		t.Fatalf("expected an unrelated AddObject to succeed on a graph with a pre-existing cycle, got: %v", err)
+	}
+}
+
+func TestAppendDependenciesStillRejectsAGenuinelyNewCycle(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.BuildWithOptions([]GraphObject{obj("a", "b"), obj("b", "a"), obj("c")}, BuildOptions{AllowCycles: true}); err != nil {
+		t.Fatalf("BuildWithOptions: %v", err)
+	}
+	if err := og.AddObject(obj("d", "c")); err != nil {
+		t.Fatalf("AddObject: %v", err)
+	}
+	if err := og.AppendDependencies("c", "d"); err == nil {
+		t.Fatal("expected a brand-new cycle (c->d->c) to still be rejected")
+	}
+}
+
+func TestRemoveThenAddReusesIDWithoutStaleCyclicEdges(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.BuildWithOptions([]GraphObject{obj("a", "b"), obj("b", "a"), obj("c")}, BuildOptions{AllowCycles: true}); err != nil {
+		t.Fatalf("BuildWithOptions: %v", err)
+	}
+	if err := og.RemoveObject("c"); err != nil {
+		t.Fatalf("RemoveObject: %v", err)
+	}
+	if err := og.AddObject(obj("d", "a")); err != nil {
+		t.Fatalf("AddObject: %v", err)
+	}
+	dID := og.namemap["d"]
+	for k := range og.cyclicEdges {
+		if k.from == dID || k.to == dID {
+			t.Errorf("expected d's reused node ID not to be misattributed as cyclic, got edge %+v", k)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/graph"
+)
+
+// Ancestors returns every object that transitively depends on the named
+// object (i.e. every object that would need it to have already run),
+// found by following incoming edges.
+func (og *ObjectGraph) Ancestors(name string) ([]GraphObject, error) {
+	id, ok := og.namemap[name]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %v", name)
+	}
+	return og.bfs(id, og.g.To), nil
+}
+
+// Descendants returns every object that the named object transitively
+// depends on, found by following outgoing edges.
+func (og *ObjectGraph) Descendants(name string) ([]GraphObject, error) {
+	id, ok := og.namemap[name]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %v", name)
+	}
+	return og.bfs(id, og.g.From), nil
+}
+
+// bfs performs a breadth-first traversal starting at id, following whichever
+// of og.g.To/og.g.From is passed as neighbors, and returns the GraphObjects
+// reached (excluding id itself and the synthetic root).
+func (og *ObjectGraph) bfs(id int64, neighbors func(int64) []graph.Node) []GraphObject {
+	seen := map[int64]bool{id: true}
+	queue := []int64{id}
+	var out []GraphObject
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, n := range neighbors(cur) {
+			if seen[n.ID()] {
+				continue
+			}
+			seen[n.ID()] = true
+			queue = append(queue, n.ID())
+			if og.idmap[n.ID()] == rootName {
+				continue
+			}
+			out = append(out, og.objs[n.ID()])
+		}
+	}
+	return out
+}
+
+// Subgraph returns a new, fully-initialized ObjectGraph restricted to the
+// named objects and everything they transitively depend on.
+func (og *ObjectGraph) Subgraph(names []string) (*ObjectGraph, error) {
+	closure := map[string]bool{}
+	var collect func(name string) error
+	collect = func(name string) error {
+		if closure[name] {
+			return nil
+		}
+		id, ok := og.namemap[name]
+		if !ok {
+			return fmt.Errorf("object not found: %v", name)
+		}
+		closure[name] = true
+		for _, d := range og.objs[id].Dependencies() {
+			if err := collect(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := collect(name); err != nil {
+			return nil, err
+		}
+	}
+	keep := make([]string, 0, len(closure))
+	for name := range closure {
+		keep = append(keep, name)
+	}
+	sort.Strings(keep)
+	objs := make([]GraphObject, len(keep))
+	for i, name := range keep {
+		objs[i] = og.objs[og.namemap[name]]
+	}
+	sg := &ObjectGraph{}
+	if err := sg.Build(objs); err != nil {
+		return nil, errors.Wrap(err, "error building subgraph")
+	}
+	return sg, nil
+}
+
+// TransitiveReduction removes every edge (u, v) for which a longer path from
+// u to v already exists through one of u's other dependencies, producing the
+// minimal graph with the same reachability relation. Levels are recomputed
+// afterward.
+func (og *ObjectGraph) TransitiveReduction() {
+	for _, u := range og.g.Nodes() {
+		children := og.g.From(u.ID())
+		for _, v := range children {
+			for _, vp := range children {
+				if vp.ID() == v.ID() {
+					continue
+				}
+				if og.reachable(vp.ID(), v.ID()) {
+					og.g.RemoveEdge(u.ID(), v.ID())
+					break
+				}
+			}
+		}
+	}
+	og.recomputeCycles()
+	og.levels = [][]GraphObject{}
+	og.calcLevels()
+}
+
+// reachable reports whether to is reachable from from by following outgoing
+// edges.
+func (og *ObjectGraph) reachable(from, to int64) bool {
+	seen := map[int64]bool{from: true}
+	queue := []int64{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to {
+			return true
+		}
+		for _, n := range og.g.From(cur) {
+			if seen[n.ID()] {
+				continue
+			}
+			seen[n.ID()] = true
+			queue = append(queue, n.ID())
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package dag
+
+import "testing"
+
+func TestAncestorsAndDescendants(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b", "c"), obj("c")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	desc, err := og.Descendants("a")
+	if err != nil {
+		t.Fatalf("Descendants: %v", err)
+	}
+	if len(desc) != 2 {
+		t.Errorf("expected a to have 2 descendants, got %d", len(desc))
+	}
+	anc, err := og.Ancestors("c")
+	if err != nil {
+		t.Fatalf("Ancestors: %v", err)
+	}
+	if len(anc) != 2 {
+		t.Errorf("expected c to have 2 ancestors, got %d", len(anc))
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b", "c"), obj("c"), obj("unrelated")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	sg, err := og.Subgraph([]string{"b"})
+	if err != nil {
+		t.Fatalf("Subgraph: %v", err)
+	}
+	if _, ok := sg.namemap["unrelated"]; ok {
+		t.Error("expected unrelated object to be excluded from the subgraph")
+	}
+	if _, ok := sg.namemap["c"]; !ok {
+		t.Error("expected b's dependency c to be included in the subgraph")
+	}
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	og := &ObjectGraph{}
+	// a depends directly on both b and c, and b also depends on c, so the
+	// direct edge a->c is redundant.
+	if err := og.Build([]GraphObject{obj("a", "b", "c"), obj("b", "c"), obj("c")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	og.TransitiveReduction()
+	if og.g.HasEdgeFromTo(og.namemap["a"], og.namemap["c"]) {
+		t.Error("expected the redundant edge a->c to be removed")
+	}
+	if !og.g.HasEdgeFromTo(og.namemap["a"], og.namemap["b"]) {
+		t.Error("expected the edge a->b to remain")
+	}
+}
@@ -0,0 +1,246 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// NodeState describes a node's lifecycle state during a Walk/WalkWith call.
+type NodeState int
+
+const (
+	Pending NodeState = iota
+	Running
+	Succeeded
+	Failed
+	Skipped
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case Pending:
+		return "Pending"
+	case Running:
+		return "Running"
+	case Succeeded:
+		return "Succeeded"
+	case Failed:
+		return "Failed"
+	case Skipped:
+		return "Skipped"
+	default:
+		return "Unknown"
+	}
+}
+
+// Direction controls which end of the graph WalkWith starts from.
+type Direction int
+
+const (
+	// LeavesFirst walks the deepest levels first and the root last, as Walk
+	// does.
+	LeavesFirst Direction = iota
+	// RootFirst walks the root level first and the deepest levels last.
+	RootFirst
+)
+
+// WalkOptions controls the behavior of WalkWith.
+type WalkOptions struct {
+	// MaxConcurrency bounds how many nodes in a level are executed
+	// concurrently. A value <= 0 means unbounded, matching Walk.
+	MaxConcurrency int
+	// Direction controls whether levels are walked leaves-first (the
+	// default, matching Walk) or root-first.
+	Direction Direction
+	// ContinueOnError causes WalkWith to keep walking the rest of the graph
+	// when a node's ActionFunc returns an error, skipping only the nodes that
+	// failure blocks rather than aborting the whole walk: for LeavesFirst,
+	// the nodes that transitively depend on it; for RootFirst, the nodes it
+	// transitively depends on (see downstreamOf). All
+	// errors encountered are collected and returned together as
+	// WalkErrors. When false (the default), WalkWith aborts on the first
+	// error, as Walk does.
+	ContinueOnError bool
+	// OnStart, if set, is called immediately before a node's ActionFunc runs.
+	OnStart func(GraphObject)
+	// OnSuccess, if set, is called after a node's ActionFunc returns nil.
+	OnSuccess func(GraphObject)
+	// OnError, if set, is called after a node's ActionFunc returns an error.
+	OnError func(GraphObject, error)
+}
+
+// WalkErrors aggregates the errors collected by a WalkWith call made with
+// WalkOptions.ContinueOnError set.
+type WalkErrors []error
+
+func (e WalkErrors) Error() string {
+	strs := make([]string, len(e))
+	for i, err := range e {
+		strs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d error(s) occurred: %s", len(e), strings.Join(strs, "; "))
+}
+
+// ActionFunc is a function that is executed for each node in the graph. Returning an error will cause the graph walk to abort,
+// unless WalkWith is called with WalkOptions.ContinueOnError set.
+type ActionFunc func(GraphObject) error
+
+// Walk traverses the graph levels in decending order, executing af for every node in a given level concurrently
+func (og *ObjectGraph) Walk(ctx context.Context, af ActionFunc) error {
+	return og.WalkWith(ctx, WalkOptions{}, af)
+}
+
+// WalkWith traverses the graph as Walk does, but additionally allows the
+// concurrency within a level to be bounded, the walk direction to be
+// reversed, and node failures to be tolerated rather than treated as fatal.
+// While a walk is in progress (and after it completes), WalkStatus reports
+// the lifecycle state of every node.
+func (og *ObjectGraph) WalkWith(ctx context.Context, opts WalkOptions, af ActionFunc) error {
+	og.statusMu.Lock()
+	og.status = make(map[string]NodeState, len(og.objs))
+	for _, o := range og.objs {
+		if o == nil { // a slot left behind by RemoveObject, not yet reused
+			continue
+		}
+		og.status[o.Name()] = Pending
+	}
+	og.statusMu.Unlock()
+
+	levelOrder := make([]int, 0, len(og.levels))
+	if opts.Direction == RootFirst {
+		for i := 0; i < len(og.levels); i++ {
+			levelOrder = append(levelOrder, i)
+		}
+	} else {
+		for i := len(og.levels) - 1; i >= 0; i-- {
+			levelOrder = append(levelOrder, i)
+		}
+	}
+
+	skipped := map[string]bool{}
+	var errs WalkErrors
+	for _, i := range levelOrder {
+		select {
+		case <-ctx.Done():
+			return errors.New("context was cancelled")
+		default:
+		}
+		g, gctx := errgroup.WithContext(ctx)
+		if opts.MaxConcurrency > 0 {
+			g.SetLimit(opts.MaxConcurrency)
+		}
+		var mu sync.Mutex
+		var levelErrs []error
+		var failed []string
+		for j := range og.levels[i] {
+			obj := og.levels[i][j]
+			if obj.Name() == rootName {
+				continue
+			}
+			if skipped[obj.Name()] {
+				og.setNodeStatus(obj.Name(), Skipped)
+				continue
+			}
+			g.Go(func() error {
+				select {
+				case <-gctx.Done():
+					return gctx.Err()
+				default:
+				}
+				og.setNodeStatus(obj.Name(), Running)
+				if opts.OnStart != nil {
+					opts.OnStart(obj)
+				}
+				if err := af(obj); err != nil {
+					og.setNodeStatus(obj.Name(), Failed)
+					if opts.OnError != nil {
+						opts.OnError(obj, err)
+					}
+					if !opts.ContinueOnError {
+						return err
+					}
+					mu.Lock()
+					levelErrs = append(levelErrs, errors.Wrapf(err, "error executing %v", obj.Name()))
+					failed = append(failed, obj.Name())
+					mu.Unlock()
+					return nil
+				}
+				og.setNodeStatus(obj.Name(), Succeeded)
+				if opts.OnSuccess != nil {
+					opts.OnSuccess(obj)
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return errors.Wrapf(err, "error executing level %v", i)
+		}
+		errs = append(errs, levelErrs...)
+		for _, name := range failed {
+			for dependent := range og.downstreamOf(og.namemap[name], opts.Direction) {
+				skipped[dependent] = true
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// downstreamOf returns the names of every node that still has yet to run
+// after id (in dir's processing order) and that id's failure should block:
+// for LeavesFirst that's whatever transitively depends on id (found by
+// following incoming edges, "To", since dependents run in later,
+// closer-to-root levels); for RootFirst it's the reverse, id's own
+// transitive dependencies (found by following outgoing edges, "From", since
+// those run in later, deeper levels).
+func (og *ObjectGraph) downstreamOf(id int64, dir Direction) map[string]bool {
+	neighbors := og.g.To
+	if dir == RootFirst {
+		neighbors = og.g.From
+	}
+	seen := map[int64]bool{}
+	var visit func(int64)
+	visit = func(k int64) {
+		for _, p := range neighbors(k) {
+			if seen[p.ID()] {
+				continue
+			}
+			seen[p.ID()] = true
+			visit(p.ID())
+		}
+	}
+	visit(id)
+	names := make(map[string]bool, len(seen))
+	for k := range seen {
+		names[og.idmap[k]] = true
+	}
+	return names
+}
+
+// setNodeStatus records the current lifecycle state of the node with the
+// given name.
+func (og *ObjectGraph) setNodeStatus(name string, s NodeState) {
+	og.statusMu.Lock()
+	og.status[name] = s
+	og.statusMu.Unlock()
+}
+
+// WalkStatus returns the lifecycle state of every node as of the most recent
+// Walk or WalkWith call. It is safe to call concurrently with an
+// in-progress walk.
+func (og *ObjectGraph) WalkStatus() map[string]NodeState {
+	og.statusMu.Lock()
+	defer og.statusMu.Unlock()
+	out := make(map[string]NodeState, len(og.status))
+	for k, v := range og.status {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestWalkRunsLeavesFirst(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var mu sync.Mutex
+	var order []string
+	err := og.Walk(context.Background(), func(o GraphObject) error {
+		mu.Lock()
+		order = append(order, o.Name())
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(order) != 2 || order[0] != "b" || order[1] != "a" {
+		t.Errorf("expected [b a], got %v", order)
+	}
+}
+
+func TestWalkWithContinueOnErrorSkipsDependents(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var mu sync.Mutex
+	ran := map[string]bool{}
+	err := og.WalkWith(context.Background(), WalkOptions{ContinueOnError: true}, func(o GraphObject) error {
+		mu.Lock()
+		ran[o.Name()] = true
+		mu.Unlock()
+		if o.Name() == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an aggregated error")
+	}
+	if ran["a"] {
+		t.Error("expected a to be skipped since its dependency b failed")
+	}
+	status := og.WalkStatus()
+	if status["a"] != Skipped {
+		t.Errorf("expected a to be marked Skipped, got %v", status["a"])
+	}
+	if status["b"] != Failed {
+		t.Errorf("expected b to be marked Failed, got %v", status["b"])
+	}
+}
+
+func TestWalkWithContinueOnErrorSkipsDependenciesRootFirst(t *testing.T) {
+	og := &ObjectGraph{}
+	// a depends on b, b depends on c, so RootFirst visits a, then b, then c.
+	if err := og.Build([]GraphObject{obj("a", "b"), obj("b", "c"), obj("c")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var mu sync.Mutex
+	ran := map[string]bool{}
+	opts := WalkOptions{Direction: RootFirst, ContinueOnError: true}
+	err := og.WalkWith(context.Background(), opts, func(o GraphObject) error {
+		mu.Lock()
+		ran[o.Name()] = true
+		mu.Unlock()
+		if o.Name() == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an aggregated error")
+	}
+	if !ran["a"] {
+		t.Error("expected a to have already run before b failed")
+	}
+	if ran["c"] {
+		t.Error("expected c to be skipped since its dependent b failed")
+	}
+	status := og.WalkStatus()
+	if status["a"] != Succeeded {
+		t.Errorf("expected a to be marked Succeeded, got %v", status["a"])
+	}
+	if status["b"] != Failed {
+		t.Errorf("expected b to be marked Failed, got %v", status["b"])
+	}
+	if status["c"] != Skipped {
+		t.Errorf("expected c to be marked Skipped, got %v", status["c"])
+	}
+}
+
+func TestWalkWithMaxConcurrency(t *testing.T) {
+	og := &ObjectGraph{}
+	if err := og.Build([]GraphObject{obj("a"), obj("b"), obj("c")}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	err := og.WalkWith(context.Background(), WalkOptions{MaxConcurrency: 1}, func(o GraphObject) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkWith: %v", err)
+	}
+	if maxRunning > 1 {
+		t.Errorf("expected at most 1 node running concurrently, saw %d", maxRunning)
+	}
+}